@@ -0,0 +1,143 @@
+package gorgonia
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"math"
+	"math/big"
+
+	"github.com/chewxy/gorgonia/tensor/types"
+)
+
+// defaultBigPrec is the default BigConstant mantissa precision, in bits.
+const defaultBigPrec = 512
+
+// OverflowError is returned in place of a silently-produced +/-Inf or NaN
+// when a BigConstant can't be rounded to its destination Dtype.
+type OverflowError struct {
+	Dtype Dtype
+	Val   *big.Float
+}
+
+func (e OverflowError) Error() string {
+	return fmt.Sprintf("constant %s overflows %v", e.Val.Text('g', 10), e.Dtype)
+}
+
+// BigConstant is a constant interface implementation (alongside
+// constantScalar and constantTensor) whose value is kept as an
+// arbitrary-precision big.Float for as long as it stays part of a purely
+// literal expression. It is only rounded down to a concrete tensor.Dtype
+// when Do is called to materialize it into a Value, so a chain of literal
+// arithmetic doesn't compound float64 rounding error at every step.
+type BigConstant struct {
+	v    *big.Float
+	dt   Dtype
+	prec uint
+}
+
+// NewBigConstant wraps v, copied at prec bits of precision (defaultBigPrec
+// if prec is 0), as a BigConstant that rounds to dt when materialized.
+func NewBigConstant(v *big.Float, dt Dtype, prec uint) BigConstant {
+	if prec == 0 {
+		prec = defaultBigPrec
+	}
+	return BigConstant{v: new(big.Float).SetPrec(prec).Set(v), dt: dt, prec: prec}
+}
+
+func (c BigConstant) Type() Type                                 { return c.dt }
+func (c BigConstant) returnsPtr() bool                           { return false }
+func (c BigConstant) callsExtern() bool                          { return false }
+func (c BigConstant) overwriteInput() int                        { return -1 }
+func (c BigConstant) DiffWRT(i int) []bool                       { return nil }
+func (c BigConstant) SymDiff(Nodes, *Node, *Node) (Nodes, error) { return nil, nil }
+
+func (c BigConstant) inferShape(Type, ...*Node) (types.Shape, error) {
+	return types.ScalarShape(), nil
+}
+
+// Do rounds c to its destination Dtype and returns the concrete Value,
+// returning an OverflowError instead of an out-of-range result if it
+// doesn't fit.
+func (c BigConstant) Do(...Value) (Value, error) {
+	switch c.dt {
+	case Float64:
+		f, _ := c.v.Float64()
+		if math.IsInf(f, 0) {
+			return nil, OverflowError{Dtype: c.dt, Val: c.v}
+		}
+		return F64(f), nil
+	case Float32:
+		f32, _ := c.v.Float32()
+		if math.IsInf(float64(f32), 0) {
+			return nil, OverflowError{Dtype: c.dt, Val: c.v}
+		}
+		return F32(f32), nil
+	case Int64:
+		i, acc := c.v.Int64()
+		if acc != big.Exact {
+			return nil, OverflowError{Dtype: c.dt, Val: c.v}
+		}
+		return I64(i), nil
+	case Int:
+		i, acc := c.v.Int64()
+		if acc != big.Exact || i > math.MaxInt32 || i < math.MinInt32 {
+			return nil, OverflowError{Dtype: c.dt, Val: c.v}
+		}
+		return I(int(i)), nil
+	default:
+		return nil, fmt.Errorf("BigConstant: unsupported dtype %v", c.dt)
+	}
+}
+
+func (c BigConstant) String() string { return fmt.Sprintf("bigconst %s", c.v.Text('g', 10)) }
+
+func (c BigConstant) WriteHash(h hash.Hash) {
+	fmt.Fprintf(h, "bigconst ")
+	if err := binary.Write(h, binary.LittleEndian, c.dt); err != nil {
+		panic(err)
+	}
+	fmt.Fprintf(h, "of %s", c.v.Text('g', 30))
+}
+
+func (c BigConstant) Hashcode() uint32 {
+	h := fnv.New32a()
+	c.WriteHash(h)
+	return h.Sum32()
+}
+
+func (c BigConstant) isconstant() bool { return true }
+
+// Value forces c to its destination dtype and panics on overflow, since the
+// constant interface's Value() has no error return. Callers that need to
+// handle overflow gracefully (the folding pass, type coercion) should call
+// Do directly instead.
+func (c BigConstant) Value() Value {
+	v, err := c.Do()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// bigAdd, bigSub, bigMul and bigQuo combine two BigConstants at the wider of
+// their two precisions, without rounding down to a concrete dtype, so that a
+// chain of literal arithmetic rounds only once, at the end.
+func bigAdd(a, b BigConstant) (BigConstant, error) { return bigBinOp(a, b, (*big.Float).Add) }
+func bigSub(a, b BigConstant) (BigConstant, error) { return bigBinOp(a, b, (*big.Float).Sub) }
+func bigMul(a, b BigConstant) (BigConstant, error) { return bigBinOp(a, b, (*big.Float).Mul) }
+func bigQuo(a, b BigConstant) (BigConstant, error) { return bigBinOp(a, b, (*big.Float).Quo) }
+
+func bigBinOp(a, b BigConstant, op func(z, x, y *big.Float) *big.Float) (BigConstant, error) {
+	if a.dt != b.dt {
+		return BigConstant{}, fmt.Errorf("BigConstant: mismatched dtypes %v and %v", a.dt, b.dt)
+	}
+	prec := a.prec
+	if b.prec > prec {
+		prec = b.prec
+	}
+	z := new(big.Float).SetPrec(prec)
+	op(z, a.v, b.v)
+	return BigConstant{v: z, dt: a.dt, prec: prec}, nil
+}
@@ -0,0 +1,75 @@
+package gorgonia
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBigConstantOverflow(t *testing.T) {
+	huge := new(big.Float).SetPrec(512).SetFloat64(1e300)
+	huge.Mul(huge, huge) // ~1e600: exact in big.Float, but doesn't fit in float64
+
+	c := NewBigConstant(huge, Float64, 0)
+
+	_, err := c.Do()
+	if err == nil {
+		t.Fatalf("expected an OverflowError, got nil")
+	}
+	if _, ok := err.(OverflowError); !ok {
+		t.Fatalf("expected an OverflowError, got %T: %v", err, err)
+	}
+}
+
+func TestBigConstantRoundsToFloat64(t *testing.T) {
+	v := new(big.Float).SetPrec(512).SetFloat64(3.5)
+	c := NewBigConstant(v, Float64, 0)
+
+	val, err := c.Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := val.(F64); !ok || float64(f) != 3.5 {
+		t.Fatalf("got %v, want 3.5", val)
+	}
+}
+
+func TestBigConstantIntegerOverflow(t *testing.T) {
+	tooBig := new(big.Float).SetPrec(512).SetInt64(1 << 40)
+	c := NewBigConstant(tooBig, Int, 0)
+
+	if _, err := c.Do(); err == nil {
+		t.Fatalf("expected a 32-bit Int constant larger than MaxInt32 to overflow")
+	}
+
+	fits := NewBigConstant(new(big.Float).SetPrec(512).SetInt64(42), Int64, 0)
+	val, err := fits.Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i, ok := val.(I64); !ok || int64(i) != 42 {
+		t.Fatalf("got %v, want 42", val)
+	}
+}
+
+func TestBigBinOpPreservesPrecision(t *testing.T) {
+	a := NewBigConstant(big.NewFloat(1e200), Float64, 0)
+	b := NewBigConstant(big.NewFloat(1e200), Float64, 0)
+
+	sum, err := bigAdd(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := new(big.Float).SetPrec(defaultBigPrec).Add(a.v, b.v)
+	if sum.v.Cmp(want) != 0 {
+		t.Fatalf("bigAdd result = %s, want %s", sum.v.Text('g', 10), want.Text('g', 10))
+	}
+}
+
+func TestBigBinOpRejectsMismatchedDtypes(t *testing.T) {
+	a := NewBigConstant(big.NewFloat(2), Float64, 0)
+	b := NewBigConstant(big.NewFloat(3), Int64, 0)
+
+	if _, err := bigAdd(a, b); err == nil {
+		t.Fatalf("expected bigAdd to reject operands with different dtypes")
+	}
+}
@@ -0,0 +1,435 @@
+package gorgonia
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/chewxy/gorgonia/tensor"
+	"github.com/chewxy/gorgonia/tensor/types"
+)
+
+// constMagic identifies a blob written by MarshalBinary, so a corrupt or
+// unrelated file is rejected before the version byte is even read.
+var constMagic = [4]byte{'G', 'C', 'N', 'S'}
+
+// gzipMagic is the standard gzip stream header. UnmarshalBinary checks for
+// it before checking constMagic, so a blob written by MarshalBinaryGzip can
+// be handed to UnmarshalBinary without the caller needing to remember
+// whether it was compressed.
+var gzipMagic = [2]byte{0x1f, 0x8b}
+
+// constFormatVersion is bumped whenever the wire layout below changes in a
+// backwards-incompatible way.
+const constFormatVersion byte = 1
+
+// dtype wire tags. These are encoded explicitly, rather than relying on
+// Dtype's own numbering, so the on-disk format stays stable even if Dtype's
+// internal representation changes.
+const (
+	tagF64 byte = iota + 1
+	tagF32
+	tagI64
+	tagI
+	tagB
+)
+
+var dtypeTags = map[Dtype]byte{
+	Float64: tagF64,
+	Float32: tagF32,
+	Int64:   tagI64,
+	Int:     tagI,
+	Bool:    tagB,
+}
+
+var tagDtypes = func() map[byte]Dtype {
+	m := make(map[byte]Dtype, len(dtypeTags))
+	for dt, tag := range dtypeTags {
+		m[tag] = dt
+	}
+	return m
+}()
+
+// MarshalBinary encodes c as a 4-byte magic, a 1-byte format version, a
+// 1-byte dtype tag, a shape header (ndims == 0 for a scalar), and the raw
+// little-endian value bytes.
+func (c constantScalar) MarshalBinary() ([]byte, error) {
+	return marshalConst(c.v.Type().(Dtype), nil, c.v)
+}
+
+// UnmarshalBinary decodes a blob written by MarshalBinary (optionally
+// gzip-wrapped, see MarshalBinaryGzip) back into a constantScalar. The
+// reconstructed value hashes identically to the original, so it interns
+// into the same constPool slot (see constpool.go) as the constant that
+// produced the blob.
+func (c *constantScalar) UnmarshalBinary(data []byte) error {
+	_, shape, v, err := unmarshalConst(data)
+	if err != nil {
+		return err
+	}
+	if len(shape) != 0 {
+		return fmt.Errorf("constcodec: expected a scalar, got shape %v", shape)
+	}
+	sv, ok := v.(Scalar)
+	if !ok {
+		return fmt.Errorf("constcodec: decoded value is not a Scalar")
+	}
+	c.v = sv
+	return nil
+}
+
+// MarshalBinaryGzip behaves like MarshalBinary but gzip-wraps the result.
+// It's meant for the large tensors (weights, embeddings) this format
+// primarily exists to checkpoint; UnmarshalBinary auto-detects and
+// transparently decompresses a gzip-wrapped blob either way.
+func (c constantScalar) MarshalBinaryGzip() ([]byte, error) {
+	blob, err := c.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return wrapGzip(blob)
+}
+
+// MarshalBinary encodes c the same way as constantScalar.MarshalBinary,
+// with a non-empty shape header describing c.v's dimensions, and the
+// tensor's backing data written out element-wise rather than as a raw
+// binary.Write of the Tensor value itself (c.v is a struct of shape/stride
+// metadata plus a backing array, not something binary.Write understands).
+func (c constantTensor) MarshalBinary() ([]byte, error) {
+	return marshalConst(c.v.Type().(Dtype), c.v.Shape(), c.v)
+}
+
+// UnmarshalBinary decodes a blob written by MarshalBinary (optionally
+// gzip-wrapped, see MarshalBinaryGzip) back into a constantTensor.
+func (c *constantTensor) UnmarshalBinary(data []byte) error {
+	_, _, v, err := unmarshalConst(data)
+	if err != nil {
+		return err
+	}
+	tv, ok := v.(Tensor)
+	if !ok {
+		return fmt.Errorf("constcodec: decoded value is not a Tensor")
+	}
+	c.v = tv
+	return nil
+}
+
+// MarshalBinaryGzip is the tensor equivalent of
+// constantScalar.MarshalBinaryGzip - the form actually worth reaching for
+// once a tensor is large enough for its raw encoding to matter.
+func (c constantTensor) MarshalBinaryGzip() ([]byte, error) {
+	blob, err := c.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return wrapGzip(blob)
+}
+
+func marshalConst(dt Dtype, shape types.Shape, v Value) ([]byte, error) {
+	tag, ok := dtypeTags[dt]
+	if !ok {
+		return nil, fmt.Errorf("constcodec: no wire tag registered for dtype %v", dt)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(constMagic[:])
+	buf.WriteByte(constFormatVersion)
+	buf.WriteByte(tag)
+
+	if err := binary.Write(&buf, binary.LittleEndian, uint16(len(shape))); err != nil {
+		return nil, err
+	}
+	for _, d := range shape {
+		if err := binary.Write(&buf, binary.LittleEndian, uint64(d)); err != nil {
+			return nil, err
+		}
+	}
+
+	raw, err := rawBacking(dt, shape, v)
+	if err != nil {
+		return nil, fmt.Errorf("constcodec: %w", err)
+	}
+	if err := binary.Write(&buf, binary.LittleEndian, raw); err != nil {
+		return nil, fmt.Errorf("constcodec: encoding data: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// rawBacking extracts the fixed-size slice (or, for a scalar, single value)
+// that binary.Write can actually serialize from v: a Scalar's own
+// underlying number for shape == nil, or a Tensor's backing array,
+// dispatched on dt since v's static type (Value) and, for a Tensor, its
+// shape/stride metadata carry no information binary.Write can use.
+func rawBacking(dt Dtype, shape types.Shape, v Value) (interface{}, error) {
+	if len(shape) == 0 {
+		return rawScalar(dt, v)
+	}
+	return rawTensorData(dt, v)
+}
+
+func rawScalar(dt Dtype, v Value) (interface{}, error) {
+	switch dt {
+	case Float64:
+		f, ok := v.(F64)
+		if !ok {
+			return nil, fmt.Errorf("expected F64, got %T", v)
+		}
+		return float64(f), nil
+	case Float32:
+		f, ok := v.(F32)
+		if !ok {
+			return nil, fmt.Errorf("expected F32, got %T", v)
+		}
+		return float32(f), nil
+	case Int64:
+		i, ok := v.(I64)
+		if !ok {
+			return nil, fmt.Errorf("expected I64, got %T", v)
+		}
+		return int64(i), nil
+	case Int:
+		i, ok := v.(I)
+		if !ok {
+			return nil, fmt.Errorf("expected I, got %T", v)
+		}
+		return int64(i), nil
+	case Bool:
+		bv, ok := v.(B)
+		if !ok {
+			return nil, fmt.Errorf("expected B, got %T", v)
+		}
+		if bv {
+			return uint8(1), nil
+		}
+		return uint8(0), nil
+	default:
+		return nil, fmt.Errorf("unsupported dtype %v", dt)
+	}
+}
+
+func rawTensorData(dt Dtype, v Value) (interface{}, error) {
+	t, ok := v.(Tensor)
+	if !ok {
+		return nil, fmt.Errorf("expected Tensor, got %T", v)
+	}
+	switch dt {
+	case Float64:
+		data, ok := t.Data().([]float64)
+		if !ok {
+			return nil, fmt.Errorf("expected []float64 backing, got %T", t.Data())
+		}
+		return data, nil
+	case Float32:
+		data, ok := t.Data().([]float32)
+		if !ok {
+			return nil, fmt.Errorf("expected []float32 backing, got %T", t.Data())
+		}
+		return data, nil
+	case Int64:
+		data, ok := t.Data().([]int64)
+		if !ok {
+			return nil, fmt.Errorf("expected []int64 backing, got %T", t.Data())
+		}
+		return data, nil
+	case Int:
+		data, ok := t.Data().([]int)
+		if !ok {
+			return nil, fmt.Errorf("expected []int backing, got %T", t.Data())
+		}
+		out := make([]int64, len(data))
+		for i, x := range data {
+			out[i] = int64(x)
+		}
+		return out, nil
+	case Bool:
+		data, ok := t.Data().([]bool)
+		if !ok {
+			return nil, fmt.Errorf("expected []bool backing, got %T", t.Data())
+		}
+		out := make([]uint8, len(data))
+		for i, x := range data {
+			if x {
+				out[i] = 1
+			}
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported dtype %v", dt)
+	}
+}
+
+func unmarshalConst(data []byte) (Dtype, types.Shape, Value, error) {
+	if bytes.HasPrefix(data, gzipMagic[:]) {
+		unwrapped, err := unwrapGzip(data)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("constcodec: decompressing: %w", err)
+		}
+		data = unwrapped
+	}
+
+	const headerLen = len(constMagic) + 1 + 1 + 2
+	if len(data) < headerLen {
+		return nil, nil, nil, fmt.Errorf("constcodec: blob too short")
+	}
+	if !bytes.Equal(data[:len(constMagic)], constMagic[:]) {
+		return nil, nil, nil, fmt.Errorf("constcodec: bad magic")
+	}
+
+	r := bytes.NewReader(data[len(constMagic):])
+
+	var version, tag byte
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, nil, nil, err
+	}
+	if version != constFormatVersion {
+		return nil, nil, nil, fmt.Errorf("constcodec: unsupported format version %d", version)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &tag); err != nil {
+		return nil, nil, nil, err
+	}
+	dt, ok := tagDtypes[tag]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("constcodec: unknown dtype tag %d", tag)
+	}
+
+	var ndims uint16
+	if err := binary.Read(r, binary.LittleEndian, &ndims); err != nil {
+		return nil, nil, nil, err
+	}
+	shape := make(types.Shape, ndims)
+	for i := range shape {
+		var d uint64
+		if err := binary.Read(r, binary.LittleEndian, &d); err != nil {
+			return nil, nil, nil, err
+		}
+		shape[i] = int(d)
+	}
+
+	v, err := newValueFromDtype(dt, shape, r)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("constcodec: decoding data: %w", err)
+	}
+	return dt, shape, v, nil
+}
+
+// newValueFromDtype is the read-side counterpart of rawBacking: it decodes
+// the raw little-endian bytes remaining in r into a concrete Scalar (when
+// shape is empty) or Tensor (built via the tensor package's own
+// constructor, so the result is a real Tensor rather than a hand-rolled
+// stand-in) of dtype dt.
+func newValueFromDtype(dt Dtype, shape types.Shape, r io.Reader) (Value, error) {
+	if len(shape) == 0 {
+		return newScalarFromDtype(dt, r)
+	}
+	return newTensorFromDtype(dt, shape, r)
+}
+
+func newScalarFromDtype(dt Dtype, r io.Reader) (Value, error) {
+	switch dt {
+	case Float64:
+		var f float64
+		if err := binary.Read(r, binary.LittleEndian, &f); err != nil {
+			return nil, err
+		}
+		return F64(f), nil
+	case Float32:
+		var f float32
+		if err := binary.Read(r, binary.LittleEndian, &f); err != nil {
+			return nil, err
+		}
+		return F32(f), nil
+	case Int64:
+		var i int64
+		if err := binary.Read(r, binary.LittleEndian, &i); err != nil {
+			return nil, err
+		}
+		return I64(i), nil
+	case Int:
+		var i int64
+		if err := binary.Read(r, binary.LittleEndian, &i); err != nil {
+			return nil, err
+		}
+		return I(int(i)), nil
+	case Bool:
+		var b uint8
+		if err := binary.Read(r, binary.LittleEndian, &b); err != nil {
+			return nil, err
+		}
+		return B(b != 0), nil
+	default:
+		return nil, fmt.Errorf("unsupported dtype %v", dt)
+	}
+}
+
+func newTensorFromDtype(dt Dtype, shape types.Shape, r io.Reader) (Value, error) {
+	n := shape.TotalSize()
+	switch dt {
+	case Float64:
+		data := make([]float64, n)
+		if err := binary.Read(r, binary.LittleEndian, data); err != nil {
+			return nil, err
+		}
+		return tensor.New(tensor.WithShape(shape...), tensor.WithBacking(data)), nil
+	case Float32:
+		data := make([]float32, n)
+		if err := binary.Read(r, binary.LittleEndian, data); err != nil {
+			return nil, err
+		}
+		return tensor.New(tensor.WithShape(shape...), tensor.WithBacking(data)), nil
+	case Int64:
+		data := make([]int64, n)
+		if err := binary.Read(r, binary.LittleEndian, data); err != nil {
+			return nil, err
+		}
+		return tensor.New(tensor.WithShape(shape...), tensor.WithBacking(data)), nil
+	case Int:
+		wire := make([]int64, n)
+		if err := binary.Read(r, binary.LittleEndian, wire); err != nil {
+			return nil, err
+		}
+		data := make([]int, n)
+		for i, x := range wire {
+			data[i] = int(x)
+		}
+		return tensor.New(tensor.WithShape(shape...), tensor.WithBacking(data)), nil
+	case Bool:
+		wire := make([]uint8, n)
+		if err := binary.Read(r, binary.LittleEndian, wire); err != nil {
+			return nil, err
+		}
+		data := make([]bool, n)
+		for i, x := range wire {
+			data[i] = x != 0
+		}
+		return tensor.New(tensor.WithShape(shape...), tensor.WithBacking(data)), nil
+	default:
+		return nil, fmt.Errorf("unsupported dtype %v", dt)
+	}
+}
+
+// wrapGzip compresses an already-encoded constant blob, for the large
+// tensors (weights, embeddings) this format is mainly meant for.
+func wrapGzip(blob []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(blob); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unwrapGzip reverses wrapGzip.
+func unwrapGzip(blob []byte) ([]byte, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+	return ioutil.ReadAll(gr)
+}
@@ -0,0 +1,48 @@
+package gorgonia
+
+import (
+	"testing"
+
+	"github.com/chewxy/gorgonia/tensor"
+)
+
+func TestConstantScalarRoundTrip(t *testing.T) {
+	c := constantScalar{v: F64(3.14)}
+
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got constantScalar
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Hashcode() != c.Hashcode() {
+		t.Fatalf("round-tripped constant hashes differently: got %d, want %d", got.Hashcode(), c.Hashcode())
+	}
+	if got.v != c.v {
+		t.Fatalf("round-tripped value = %v, want %v", got.v, c.v)
+	}
+}
+
+func TestConstantTensorRoundTripGzip(t *testing.T) {
+	backing := []float64{1, 2, 3, 4, 5, 6}
+	tv := tensor.New(tensor.WithShape(2, 3), tensor.WithBacking(backing))
+	c := constantTensor{v: tv}
+
+	data, err := c.MarshalBinaryGzip()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got constantTensor
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Hashcode() != c.Hashcode() {
+		t.Fatalf("round-tripped tensor constant hashes differently: got %d, want %d", got.Hashcode(), c.Hashcode())
+	}
+}
@@ -0,0 +1,141 @@
+package gorgonia
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// constPool hash-conses constant nodes: it returns the same *Node for any
+// two structurally-equal constants, keyed on the constant op's Hashcode()
+// and confirmed with a deep-equality check on its Value.
+type constPool struct {
+	sync.Mutex
+	byHash map[uint32][]*Node
+}
+
+func newConstPool() *constPool {
+	return &constPool{byHash: make(map[uint32][]*Node)}
+}
+
+// globalConstPool backs NewConstant/NewScalar when no *ExprGraph-scoped pool
+// applies, e.g. for constants built before they're attached to a graph.
+var globalConstPool = newConstPool()
+
+// intern returns the canonical *Node for c, calling create to build one the
+// first time a constant with this hash and value is seen.
+func (p *constPool) intern(c constant, create func() *Node) *Node {
+	p.Lock()
+	defer p.Unlock()
+
+	h := c.Hashcode()
+	for _, cand := range p.byHash[h] {
+		candC, ok := cand.op.(constant)
+		if ok && reflect.DeepEqual(candC.Value(), c.Value()) {
+			return cand
+		}
+	}
+
+	n := create()
+	p.byHash[h] = append(p.byHash[h], n)
+	return n
+}
+
+// pool returns g's constant pool, lazily creating one on first use.
+func (g *ExprGraph) pool() *constPool {
+	if g.consts == nil {
+		g.consts = newConstPool()
+	}
+	return g.consts
+}
+
+// NodeConsOpt configures a newly constructed *Node, e.g. giving it a name.
+type NodeConsOpt func(*Node)
+
+// WithName gives a newly constructed Node a name.
+func WithName(name string) NodeConsOpt {
+	return func(n *Node) { n.name = name }
+}
+
+// newInternedConstant is the choke point NewConstant, NewScalar, the
+// internal constant constructors, and FoldConstants' replacement nodes all
+// go through, routed via g's pool (or globalConstPool if g is nil).
+func newInternedConstant(g *ExprGraph, op constant, opts ...NodeConsOpt) *Node {
+	p := globalConstPool
+	if g != nil {
+		p = g.pool()
+	}
+	return p.intern(op, func() *Node {
+		n := &Node{g: g, op: op, t: op.Type()}
+		for _, opt := range opts {
+			opt(n)
+		}
+		if g != nil {
+			g.all = append(g.all, n)
+		}
+		return n
+	})
+}
+
+// newConstantScalar is the pooled constructor constantScalar values go
+// through internally, e.g. from FoldConstants or from literal scalars
+// encountered while building a graph.
+func newConstantScalar(g *ExprGraph, v Scalar, opts ...NodeConsOpt) *Node {
+	return newInternedConstant(g, constantScalar{v: v}, opts...)
+}
+
+// newConstantTensor is the tensor-valued equivalent of newConstantScalar.
+func newConstantTensor(g *ExprGraph, v Tensor, opts ...NodeConsOpt) *Node {
+	return newInternedConstant(g, constantTensor{v: v}, opts...)
+}
+
+// internConstantValue wraps val - the already-computed result of folding a
+// node, see fold.go - as a constantScalar or constantTensor node, routed
+// through the pool the same way NewConstant is.
+func internConstantValue(g *ExprGraph, val Value) *Node {
+	switch v := val.(type) {
+	case Scalar:
+		return newConstantScalar(g, v)
+	case Tensor:
+		return newConstantTensor(g, v)
+	default:
+		panic(fmt.Sprintf("internConstantValue: %T is neither a Scalar nor a Tensor", val))
+	}
+}
+
+// NewConstant returns a constant *Node wrapping v, reusing an existing
+// *Node for any structurally-equal v already seen.
+func NewConstant(v Value, opts ...NodeConsOpt) *Node {
+	switch val := v.(type) {
+	case Scalar:
+		return newConstantScalar(nil, val, opts...)
+	case Tensor:
+		return newConstantTensor(nil, val, opts...)
+	default:
+		panic(fmt.Sprintf("NewConstant: %T is neither a Scalar nor a Tensor", v))
+	}
+}
+
+// NewScalar returns a constant scalar *Node of dtype dt and zero value,
+// attached to g and pooled the same way NewConstant is.
+func NewScalar(g *ExprGraph, dt Dtype, opts ...NodeConsOpt) *Node {
+	return newConstantScalar(g, zeroValue(dt), opts...)
+}
+
+// zeroValue returns dt's zero value as a Scalar, for use by NewScalar.
+func zeroValue(dt Dtype) Scalar {
+	switch dt {
+	case Float64:
+		return F64(0)
+	case Float32:
+		return F32(0)
+	case Int64:
+		return I64(0)
+	case Int:
+		return I(0)
+	case Bool:
+		return B(false)
+	default:
+		panic(fmt.Sprintf("zeroValue: unsupported dtype %v", dt))
+	}
+}
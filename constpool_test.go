@@ -0,0 +1,37 @@
+package gorgonia
+
+import "testing"
+
+func TestNewConstantDedup(t *testing.T) {
+	a := NewConstant(F64(0))
+	b := NewConstant(F64(0))
+	if a != b {
+		t.Fatalf("expected two NewConstant(F64(0)) calls to intern to the same *Node, got %p and %p", a, b)
+	}
+
+	c := NewConstant(F64(1))
+	if a == c {
+		t.Fatalf("expected NewConstant(F64(0)) and NewConstant(F64(1)) to produce distinct *Node")
+	}
+}
+
+func TestNewScalarDedup(t *testing.T) {
+	g := &ExprGraph{}
+	a := NewScalar(g, Float64)
+	b := NewScalar(g, Float64)
+	if a != b {
+		t.Fatalf("expected two NewScalar(g, Float64) calls to intern to the same *Node, got %p and %p", a, b)
+	}
+}
+
+// BenchmarkNewConstantDedup exercises the common case this pool exists for:
+// a model repeatedly constructing the same literal (e.g. a zero used for
+// padding/masking). Once the first Node is interned, every further call
+// should find it in the pool's hash bucket rather than allocating a new
+// Node and op.
+func BenchmarkNewConstantDedup(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NewConstant(F64(0))
+	}
+}
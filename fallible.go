@@ -0,0 +1,80 @@
+package gorgonia
+
+import "fmt"
+
+// Constraint is a symbolic precondition, attached to a specific Op
+// application, that must hold once its Inputs become concrete constants.
+// It's emitted by AnalyzeFallible, and FoldConstants re-checks it once
+// folding has reached its fixed point.
+type Constraint struct {
+	Op     Op
+	Node   *Node
+	Inputs Nodes
+
+	// reason names the precondition, e.g. "division by zero", for use in
+	// the error Check returns when it's violated.
+	reason string
+	check  func(inputs Nodes) error
+}
+
+// NewConstraint builds a Constraint that reports reason when check fails
+// against its concrete Inputs. Third-party Ops use this from their
+// ConstraintProvider.Constraints implementation, since reason and check are
+// unexported.
+func NewConstraint(reason string, check func(inputs Nodes) error) Constraint {
+	return Constraint{reason: reason, check: check}
+}
+
+// Check evaluates c against its current Inputs. If any input isn't yet a
+// concrete constant, the constraint simply can't be evaluated and Check
+// returns nil; it's expected to be re-run once folding or Let makes more
+// inputs concrete.
+func (c Constraint) Check() error {
+	for _, in := range c.Inputs {
+		if _, ok := in.op.(constant); !ok {
+			return nil
+		}
+	}
+	if err := c.check(c.Inputs); err != nil {
+		return fmt.Errorf("%v: %s: %w", c.Node, c.reason, err)
+	}
+	return nil
+}
+
+// ConstraintProvider is the interface through which an Op whose correctness
+// depends on a runtime-constant precondition (Div/Mod by a non-zero divisor,
+// Sqrt of a non-negative operand, Log of a strictly positive operand, a
+// Reshape whose target dims multiply out to the input size, a Slice whose
+// indices fit within a dim, a MatMul whose inner dimensions agree, and so on)
+// opts into AnalyzeFallible's compile-time checking instead of failing only
+// once VM.RunAll() actually runs it. No op in this package implements it, so
+// AnalyzeFallible reports only constraints from ops that do.
+type ConstraintProvider interface {
+	Constraints(inputs Nodes) []Constraint
+}
+
+// AnalyzeFallible walks g and collects the Constraints emitted by every
+// node whose Op implements ConstraintProvider, checking each one as it
+// goes. A violated constraint is reported immediately, naming the offending
+// op and node, so the caller learns about it at graph-compile time rather
+// than at first VM.RunAll(). Constraints whose inputs aren't concrete yet
+// are still returned so the caller can re-check them - e.g. after
+// FoldConstants or Let have bound more of the graph to constants.
+func AnalyzeFallible(g *ExprGraph) ([]Constraint, error) {
+	var constraints []Constraint
+	for _, n := range g.all {
+		cp, ok := n.op.(ConstraintProvider)
+		if !ok {
+			continue
+		}
+		for _, c := range cp.Constraints(n.children) {
+			c.Op = n.op
+			c.Node = n
+			if err := c.Check(); err != nil {
+				return constraints, err
+			}
+			constraints = append(constraints, c)
+		}
+	}
+	return constraints, nil
+}
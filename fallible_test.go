@@ -0,0 +1,86 @@
+package gorgonia
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestConstraintCheck(t *testing.T) {
+	zero := &Node{op: constantScalar{v: F64(0)}}
+	nonzero := &Node{op: constantScalar{v: F64(2)}}
+
+	divisorMustBeNonZero := func(inputs Nodes) error {
+		divisor := inputs[1].op.(constant).Value().(F64)
+		if divisor == 0 {
+			return fmt.Errorf("divisor is zero")
+		}
+		return nil
+	}
+
+	violated := NewConstraint("division by zero", divisorMustBeNonZero)
+	violated.Inputs = Nodes{nonzero, zero}
+	if err := violated.Check(); err == nil {
+		t.Fatalf("expected a division-by-zero violation to be reported")
+	}
+
+	ok := NewConstraint("division by zero", divisorMustBeNonZero)
+	ok.Inputs = Nodes{nonzero, nonzero}
+	if err := ok.Check(); err != nil {
+		t.Fatalf("did not expect a violation, got %v", err)
+	}
+}
+
+func TestConstraintCheckDefersOnNonConstantInput(t *testing.T) {
+	symbolic := &Node{}
+	nonzero := &Node{op: constantScalar{v: F64(2)}}
+
+	c := Constraint{
+		Inputs: Nodes{nonzero, symbolic},
+		reason: "division by zero",
+		check: func(Nodes) error {
+			t.Fatalf("check should not run until every input is a constant")
+			return nil
+		},
+	}
+	if err := c.Check(); err != nil {
+		t.Fatalf("expected Check to defer rather than report an error, got %v", err)
+	}
+}
+
+// thirdPartyDivOp stands in for a ConstraintProvider implemented outside
+// package gorgonia, which can only build a Constraint via NewConstraint.
+type thirdPartyDivOp struct{ constantScalar }
+
+func (thirdPartyDivOp) Constraints(inputs Nodes) []Constraint {
+	c := NewConstraint("division by zero", func(inputs Nodes) error {
+		if inputs[1].op.(constant).Value().(F64) == 0 {
+			return fmt.Errorf("divisor is zero")
+		}
+		return nil
+	})
+	c.Inputs = inputs
+	return []Constraint{c}
+}
+
+func TestAnalyzeFallibleReportsThirdPartyConstraint(t *testing.T) {
+	numerator := &Node{op: constantScalar{v: F64(1)}}
+	zero := &Node{op: constantScalar{v: F64(0)}}
+	divNode := &Node{op: thirdPartyDivOp{}, children: Nodes{numerator, zero}}
+
+	g := &ExprGraph{all: Nodes{numerator, zero, divNode}}
+	if _, err := AnalyzeFallible(g); err == nil {
+		t.Fatalf("expected AnalyzeFallible to report the division-by-zero constraint")
+	}
+}
+
+func TestAnalyzeFallibleSkipsNonConstraintOps(t *testing.T) {
+	g := &ExprGraph{all: Nodes{&Node{op: constantScalar{v: F64(1)}}}}
+
+	constraints, err := AnalyzeFallible(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(constraints) != 0 {
+		t.Fatalf("expected no constraints from an op that doesn't implement ConstraintProvider, got %d", len(constraints))
+	}
+}
@@ -0,0 +1,176 @@
+package gorgonia
+
+import "fmt"
+
+// FoldOpt configures the behaviour of FoldConstants.
+type FoldOpt func(*foldState)
+
+// WithExternFolding allows FoldConstants to fold ops that call into external
+// (cgo or CUDA) code, at the cost of a device transfer for each one folded.
+func WithExternFolding(allow bool) FoldOpt {
+	return func(s *foldState) { s.allowExtern = allow }
+}
+
+type foldState struct {
+	allowExtern bool
+}
+
+// FoldConstants replaces every node of g whose inputs are all constants
+// with a single constant node holding the eagerly-computed result,
+// iterating to a fixed point so e.g. Add(Mul(c1, c2), c3) collapses fully.
+// callsExtern() ops are skipped unless WithExternFolding(true) is passed,
+// and a panic from Op.Do leaves its subgraph un-folded instead of aborting
+// the pass. Once the fixed point is reached, every ConstraintProvider
+// constraint in g is re-checked via AnalyzeFallible and the first violation
+// is returned.
+func FoldConstants(g *ExprGraph, opts ...FoldOpt) error {
+	s := new(foldState)
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	for {
+		folded, err := foldPass(g, s)
+		if err != nil {
+			return err
+		}
+		if folded == 0 {
+			_, err := AnalyzeFallible(g)
+			return err
+		}
+	}
+}
+
+// foldPass makes a single sweep over g, folding every node that is
+// currently foldable. It returns the number of nodes folded.
+func foldPass(g *ExprGraph, s *foldState) (int, error) {
+	folded := 0
+	for _, n := range g.all {
+		if !foldable(n, s) {
+			continue
+		}
+
+		// Keep BigConstant arithmetic in big.Float form; validate via Do
+		// before interning, since constPool.intern calls Value(), which
+		// panics on overflow.
+		if bc, ok := evalBigFold(n); ok {
+			if _, err := bc.Do(); err != nil {
+				continue
+			}
+			cn := newInternedConstant(g, bc)
+			if err := replaceNode(g, n, cn); err != nil {
+				return folded, fmt.Errorf("fold: replacing %v with %v: %w", n, cn, err)
+			}
+			folded++
+			continue
+		}
+
+		val, err := evalFold(n)
+		if err != nil {
+			// leave this node as-is; a later pass, after a sibling subgraph
+			// has folded, may make it foldable (or not) again.
+			continue
+		}
+
+		// Route through the constant pool so equal folded values converge.
+		cn := internConstantValue(g, val)
+		if err := replaceNode(g, n, cn); err != nil {
+			return folded, fmt.Errorf("fold: replacing %v with %v: %w", n, cn, err)
+		}
+		folded++
+	}
+	return folded, nil
+}
+
+// arithOp is the interface through which a binary arithmetic op (add, sub,
+// mul, div) opts into BigConstant folding below, which combines two operands
+// symbolically without needing to know their concrete Op type. No op in this
+// package implements it; an op that does not is folded only through evalFold.
+type arithOp interface {
+	arithSymbol() byte // '+', '-', '*', or '/'
+}
+
+// evalBigFold folds n in arbitrary precision when n.op is an arithOp and
+// both of its children are already BigConstants, returning ok == false
+// otherwise so the caller falls back to the regular evalFold path.
+func evalBigFold(n *Node) (result BigConstant, ok bool) {
+	ao, isArith := n.op.(arithOp)
+	if !isArith || len(n.children) != 2 {
+		return BigConstant{}, false
+	}
+	a, aok := n.children[0].op.(BigConstant)
+	b, bok := n.children[1].op.(BigConstant)
+	if !aok || !bok {
+		return BigConstant{}, false
+	}
+
+	var bigOp func(BigConstant, BigConstant) (BigConstant, error)
+	switch ao.arithSymbol() {
+	case '+':
+		bigOp = bigAdd
+	case '-':
+		bigOp = bigSub
+	case '*':
+		bigOp = bigMul
+	case '/':
+		bigOp = bigQuo
+	default:
+		return BigConstant{}, false
+	}
+
+	res, err := bigOp(a, b)
+	if err != nil {
+		return BigConstant{}, false
+	}
+	return res, true
+}
+
+// foldable reports whether n's op has every input already bound to a
+// constant, is not itself already a constant, and is allowed to run given
+// s.allowExtern.
+func foldable(n *Node, s *foldState) bool {
+	if n.op == nil || len(n.children) == 0 {
+		return false
+	}
+	if _, ok := n.op.(constant); ok {
+		return false
+	}
+	if n.op.callsExtern() && !s.allowExtern {
+		return false
+	}
+	for _, child := range n.children {
+		if _, ok := child.op.(constant); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// evalFold executes n's op against its already-constant children,
+// recovering from any panic so a broken subgraph doesn't abort the pass.
+func evalFold(n *Node) (val Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("fold: panic evaluating %v: %v", n, r)
+		}
+	}()
+
+	inputs := make([]Value, len(n.children))
+	for i, child := range n.children {
+		inputs[i] = child.op.(constant).Value()
+	}
+	return n.op.Do(inputs...)
+}
+
+// replaceNode rewires every reference to n (wherever it appears as a child
+// elsewhere in g) to point at cn instead, then removes n from the graph.
+func replaceNode(g *ExprGraph, n, cn *Node) error {
+	for _, parent := range g.all {
+		for i, child := range parent.children {
+			if child == n {
+				parent.children[i] = cn
+			}
+		}
+	}
+	return g.removeNode(n)
+}
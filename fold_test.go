@@ -0,0 +1,82 @@
+package gorgonia
+
+import (
+	"fmt"
+	"hash"
+	"math/big"
+	"testing"
+
+	"github.com/chewxy/gorgonia/tensor/types"
+)
+
+// addOp is a minimal stand-in for the real elementary add op - just enough
+// to exercise FoldConstants without pulling in the rest of the op zoo.
+type addOp struct{}
+
+func (addOp) Type() Type                                     { return Float64 }
+func (addOp) inferShape(Type, ...*Node) (types.Shape, error) { return types.ScalarShape(), nil }
+func (addOp) DiffWRT(int) []bool                             { return []bool{true, true} }
+func (addOp) SymDiff(Nodes, *Node, *Node) (Nodes, error)     { return nil, nil }
+func (addOp) Do(vs ...Value) (Value, error) {
+	return F64(vs[0].(F64) + vs[1].(F64)), nil
+}
+func (addOp) returnsPtr() bool      { return false }
+func (addOp) callsExtern() bool     { return false }
+func (addOp) overwriteInput() int   { return -1 }
+func (addOp) WriteHash(h hash.Hash) { fmt.Fprint(h, "add") }
+func (addOp) Hashcode() uint32      { return 1 }
+func (addOp) String() string        { return "+" }
+func (addOp) arithSymbol() byte     { return '+' }
+
+func TestFoldableRequiresAllConstantChildren(t *testing.T) {
+	c := &Node{op: constantScalar{v: F64(2)}}
+	symbolic := &Node{}
+	mixed := &Node{op: addOp{}, children: Nodes{c, symbolic}}
+
+	if foldable(mixed, new(foldState)) {
+		t.Fatalf("a node with a non-constant child should not be foldable")
+	}
+
+	allConst := &Node{op: addOp{}, children: Nodes{c, c}}
+	if !foldable(allConst, new(foldState)) {
+		t.Fatalf("a node whose children are all constants should be foldable")
+	}
+}
+
+func TestEvalFoldRecoversPanic(t *testing.T) {
+	c := &Node{op: constantScalar{v: F64(2)}}
+	n := &Node{op: panicOp{}, children: Nodes{c, c}}
+
+	if _, err := evalFold(n); err == nil {
+		t.Fatalf("expected evalFold to turn a panic in Do into an error")
+	}
+}
+
+type panicOp struct{ addOp }
+
+func (panicOp) Do(...Value) (Value, error) { panic("divide by zero") }
+
+func TestEvalBigFoldKeepsArbitraryPrecision(t *testing.T) {
+	a := NewBigConstant(big.NewFloat(1e308), Float64, 0)
+	b := NewBigConstant(big.NewFloat(1e308), Float64, 0)
+
+	n := &Node{op: addOp{}, children: Nodes{
+		{op: a},
+		{op: b},
+	}}
+
+	result, ok := evalBigFold(n)
+	if !ok {
+		t.Fatalf("expected evalBigFold to handle a node whose op is an arithOp over two BigConstants")
+	}
+
+	// 1e308 + 1e308 = 2e308, which is exactly representable in big.Float
+	// but exceeds math.MaxFloat64 (about 1.8e308) - evalBigFold must not
+	// have rounded it down to float64 (+Inf) along the way.
+	if result.v.IsInf() {
+		t.Fatalf("expected evalBigFold to keep 2e308 as a finite big.Float, got %s", result.v.Text('g', 10))
+	}
+	if _, err := result.Do(); err == nil {
+		t.Fatalf("expected the final materialization to report an overflow")
+	}
+}